@@ -0,0 +1,14 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+// Blob is a blob object, the content of a file in a tree: the exec-based and gogit-based
+// alternative implementations this type needed. Reading its content (Data, in blob_nogogit.go
+// and blob_gogit.go) is backend-specific; everything else about it is just the TreeEntry it was
+// created from.
+type Blob struct {
+	repo *Repository
+	*TreeEntry
+}
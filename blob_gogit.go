@@ -0,0 +1,29 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build gogit
+// +build gogit
+
+package git
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Data returns a reader for the blob's content, read directly from the decoded object in the
+// repository's object store instead of forking a `git cat-file` process.
+func (b *Blob) Data() (io.Reader, error) {
+	obj, err := b.repo.gogitRepo.BlobObject(plumbing.Hash(b.ID))
+	if err != nil {
+		return nil, fmt.Errorf("get blob object: %v", err)
+	}
+	r, err := obj.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("read blob object: %v", err)
+	}
+	return r, nil
+}
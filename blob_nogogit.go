@@ -0,0 +1,28 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Data returns a reader for the blob's content, read via the repository's long-lived
+// `git cat-file --batch` reader rather than forking a `git cat-file` process per call.
+func (b *Blob) Data() (io.Reader, error) {
+	batch, err := repoCatFileBatch(b.repo)
+	if err != nil {
+		return nil, err
+	}
+	_, data, err := batch.content.Contents(b.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("cat-file --batch: %v", err)
+	}
+	return bytes.NewReader(data), nil
+}
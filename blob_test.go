@@ -0,0 +1,41 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import (
+	"io"
+	"testing"
+)
+
+func TestBlob_Data(t *testing.T) {
+	repo, commit, _ := setupCommitsInfoFixture(t, 3)
+	defer repo.Close()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+
+	for _, te := range entries {
+		r, err := te.Blob().Data()
+		if err != nil {
+			t.Fatalf("Data(%q): %v", te.Name(), err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("ReadAll(%q): %v", te.Name(), err)
+		}
+		if string(content) != te.Name() {
+			t.Errorf("Data(%q) = %q, want %q", te.Name(), content, te.Name())
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// catFileBatchCloseTimeout bounds how long Close waits for `git cat-file` to exit on its own
+// after its stdin is closed, before falling back to killing the process.
+const catFileBatchCloseTimeout = 5 * time.Second
+
+// CatFileBatchHeader is the metadata `git cat-file --batch[-check]` reports for an object ahead
+// of (or instead of) its content.
+type CatFileBatchHeader struct {
+	SHA  string
+	Type ObjectType
+	Size int64
+}
+
+// CatFileBatch wraps a single long-lived `git cat-file --batch` or `--batch-check` subprocess so
+// repeated object lookups reuse one process instead of forking a new one per call. Requests are
+// serialized: write the object's SHA, read back its header (and, for a `--batch` reader, its
+// content).
+type CatFileBatch struct {
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stdinPipe io.WriteCloser
+	stdin     *bufio.Writer
+	stdout    *bufio.Reader
+	closed    bool
+}
+
+// newCatFileBatch starts `git cat-file <mode>` in repoPath. mode is either
+// "--batch-check=%(objectsize) %(objecttype) %(objectname)" for metadata-only lookups, or
+// "--batch" to also stream object content.
+func newCatFileBatch(repoPath, mode string) (*CatFileBatch, error) {
+	cmd := exec.Command("git", "cat-file", mode)
+	cmd.Dir = repoPath
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start: %v", err)
+	}
+
+	return &CatFileBatch{
+		cmd:       cmd,
+		stdinPipe: stdin,
+		stdin:     bufio.NewWriter(stdin),
+		stdout:    bufio.NewReader(stdout),
+	}, nil
+}
+
+// Check looks up sha's header via a "--batch-check" reader, without reading any content.
+func (b *CatFileBatch) Check(sha string) (*CatFileBatchHeader, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.request(sha); err != nil {
+		return nil, err
+	}
+	return b.readHeader()
+}
+
+// Contents looks up sha's header and full content via a "--batch" reader.
+func (b *CatFileBatch) Contents(sha string) (*CatFileBatchHeader, []byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.request(sha); err != nil {
+		return nil, nil, err
+	}
+	header, err := b.readHeader()
+	if err != nil {
+		return nil, nil, err
+	}
+	data := make([]byte, header.Size)
+	if _, err := io.ReadFull(b.stdout, data); err != nil {
+		return nil, nil, fmt.Errorf("read content: %v", err)
+	}
+	if _, err := b.stdout.Discard(1); err != nil { // trailing newline after the content
+		return nil, nil, fmt.Errorf("discard trailing newline: %v", err)
+	}
+	return header, data, nil
+}
+
+func (b *CatFileBatch) request(sha string) error {
+	if b.closed {
+		return fmt.Errorf("cat-file batch reader is closed")
+	}
+	if _, err := fmt.Fprintf(b.stdin, "%s\n", sha); err != nil {
+		return fmt.Errorf("write request: %v", err)
+	}
+	return b.stdin.Flush()
+}
+
+// readHeader reads one header line and accepts either the "--batch"/"--batch-check" default
+// layout ("<sha> <type> <size>") or the custom "<size> <type> <sha>" layout this package asks
+// "--batch-check" to use.
+func (b *CatFileBatch) readHeader() (*CatFileBatchHeader, error) {
+	line, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read header: %v", err)
+	}
+	line = strings.TrimSuffix(line, "\n")
+	if strings.HasSuffix(line, " missing") {
+		return nil, fmt.Errorf("object %s does not exist", strings.TrimSuffix(line, " missing"))
+	}
+
+	parts := strings.SplitN(line, " ", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed cat-file header %q", line)
+	}
+
+	// The custom "--batch-check" format this package uses reports size first; fall back to
+	// treating parts[0] as the SHA (the default layout) if it doesn't parse as a size.
+	if size, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+		return &CatFileBatchHeader{SHA: parts[2], Type: ObjectType(parts[1]), Size: size}, nil
+	}
+	size, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse size from header %q: %v", line, err)
+	}
+	return &CatFileBatchHeader{SHA: parts[0], Type: ObjectType(parts[1]), Size: size}, nil
+}
+
+// Close tells the subprocess to exit by closing its stdin, which makes `git cat-file` reach EOF
+// and stop on its own with a clean exit. It only falls back to killing the process if that
+// doesn't happen within catFileBatchCloseTimeout, e.g. because the process is wedged. It is safe
+// to call more than once.
+func (b *CatFileBatch) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	_ = b.stdin.Flush()
+	if err := b.stdinPipe.Close(); err != nil {
+		_ = b.cmd.Process.Kill()
+		_ = b.cmd.Wait()
+		return fmt.Errorf("close stdin: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- b.cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(catFileBatchCloseTimeout):
+		_ = b.cmd.Process.Kill()
+		<-done
+		return fmt.Errorf("cat-file subprocess did not exit within %s, killed it", catFileBatchCloseTimeout)
+	}
+}
+
+// catFileBatchPair is the pair of long-lived readers kept open per repository: one for metadata
+// lookups, one for content lookups.
+type catFileBatchPair struct {
+	check   *CatFileBatch
+	content *CatFileBatch
+}
+
+// catFileBatchRegistry holds one catFileBatchPair per *Repository instance. It is keyed by the
+// repository handle itself, not its path, so that this package can wire in batch reuse without
+// changing the Repository struct's layout, while still giving two *Repository values opened for
+// the same path independent subprocess pairs — otherwise Close on one handle would tear down the
+// readers a sibling handle for the same path is still using.
+var catFileBatchRegistry sync.Map // map[*Repository]*catFileBatchPair
+
+func repoCatFileBatch(repo *Repository) (*catFileBatchPair, error) {
+	if v, ok := catFileBatchRegistry.Load(repo); ok {
+		return v.(*catFileBatchPair), nil
+	}
+
+	check, err := newCatFileBatch(repo.Path, "--batch-check=%(objectsize) %(objecttype) %(objectname)")
+	if err != nil {
+		return nil, fmt.Errorf("start batch-check reader: %v", err)
+	}
+	content, err := newCatFileBatch(repo.Path, "--batch")
+	if err != nil {
+		check.Close()
+		return nil, fmt.Errorf("start batch reader: %v", err)
+	}
+
+	pair := &catFileBatchPair{check: check, content: content}
+	if actual, loaded := catFileBatchRegistry.LoadOrStore(repo, pair); loaded {
+		pair.check.Close()
+		pair.content.Close()
+		return actual.(*catFileBatchPair), nil
+	}
+	return pair, nil
+}
+
+// Close shuts down any long-lived `git cat-file --batch` subprocesses started for repo. It is
+// safe to call even if none were ever started, and safe to call more than once. It only affects
+// this *Repository handle: another handle open on the same path keeps its own readers.
+func (repo *Repository) Close() error {
+	v, ok := catFileBatchRegistry.LoadAndDelete(repo)
+	if !ok {
+		return nil
+	}
+	pair := v.(*catFileBatchPair)
+	errCheck := pair.check.Close()
+	errContent := pair.content.Close()
+	if errCheck != nil {
+		return errCheck
+	}
+	return errContent
+}
@@ -0,0 +1,54 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import "testing"
+
+func TestTreeEntry_Size_Batch(t *testing.T) {
+	repo, commit, _ := setupCommitsInfoFixture(t, 5)
+	defer repo.Close()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	for _, te := range entries {
+		if got, want := te.Size(), int64(len(te.Name())); got != want {
+			t.Errorf("Size(%q) = %d, want %d", te.Name(), got, want)
+		}
+	}
+}
+
+// BenchmarkTreeEntry_Size_Batch measures Size() across a tree large enough (>=1000 entries) for
+// the one-process-per-repository cat-file batch reader to show its win over forking
+// `git cat-file -s` once per entry.
+func BenchmarkTreeEntry_Size_Batch(b *testing.B) {
+	repo, commit, _ := setupCommitsInfoFixture(b, 1000)
+	defer repo.Close()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		b.Fatalf("Tree: %v", err)
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		b.Fatalf("ListEntries: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, te := range entries {
+			te.sized = false
+			te.Size()
+		}
+	}
+}
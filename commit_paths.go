@@ -0,0 +1,101 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+)
+
+// GetLastCommitForPaths resolves the newest commit that touched each of paths, as of c, in a
+// single `git log --name-only` walk. It parses the output incrementally and stops as soon as
+// every path has been resolved, rather than waiting for the whole walk to finish.
+//
+// This is a lower-level primitive than Entries.GetCommitsInfo: callers that already have an
+// arbitrary set of paths to resolve (e.g. search results, or a list of LFS files) can use it
+// directly, instead of going through a Tree.
+func (c *Commit) GetLastCommitForPaths(paths []string) (map[string]*Commit, error) {
+	result := make(map[string]*Commit, len(paths))
+	if len(paths) == 0 {
+		return result, nil
+	}
+
+	remaining := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		remaining[p] = true
+	}
+
+	args := append([]string{"log", prettyLogFormat, "--name-only", c.ID.String(), "--"}, paths...)
+	cmd := newGitCmd(context.Background(), c.repo.Path, nil, args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start: %v", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var currentHash string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if isHexSHA1(line) {
+			currentHash = line
+			continue
+		}
+		if !remaining[line] {
+			continue
+		}
+		commit, err := c.repo.GetCommit(currentHash)
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return nil, fmt.Errorf("get commit %s: %v", currentHash, err)
+		}
+		result[line] = commit
+		delete(remaining, line)
+		if len(remaining) == 0 {
+			break
+		}
+	}
+
+	// Killing the process once we have everything we need (rather than waiting for the rest of
+	// history to be walked) is the whole point of parsing incrementally; exec reports that as an
+	// error from Wait, so only surface a real failure when some paths are still unresolved.
+	if len(remaining) > 0 {
+		if err := cmd.Wait(); err != nil {
+			return nil, fmt.Errorf("git log --name-only: %v", err)
+		}
+		return nil, fmt.Errorf("git log --name-only: not all paths were touched by any commit")
+	}
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+
+	return result, nil
+}
+
+// isHexSHA1 reports whether s looks like a full SHA-1 object ID, as opposed to a file path.
+func isHexSHA1(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'f':
+		case r >= 'A' && r <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import "testing"
+
+func TestCommit_GetLastCommitForPaths(t *testing.T) {
+	_, commit, _ := setupCommitsInfoFixture(t, 5)
+
+	paths := []string{"file-0.txt", "file-4.txt"}
+	result, err := commit.GetLastCommitForPaths(paths)
+	if err != nil {
+		t.Fatalf("GetLastCommitForPaths: %v", err)
+	}
+	if len(result) != len(paths) {
+		t.Fatalf("got %d results, want %d", len(result), len(paths))
+	}
+	for _, p := range paths {
+		if result[p] == nil {
+			t.Errorf("path %q was not resolved", p)
+		}
+	}
+}
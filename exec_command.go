@@ -0,0 +1,26 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// newGitCmd builds an *exec.Cmd for "git <args...>" run in dir. It exists for the handful of
+// call sites (GetLastCommitForPaths, PushWithOptions) that need direct control over the
+// subprocess — a stdout pipe read incrementally, or a custom environment/timeout — that
+// NewCommand's RunInDir doesn't expose, so that control is built in one consistent place instead
+// of each call site reinventing it. ctx may be context.Background() if no timeout is needed; env
+// is appended to the subprocess's environment, leave nil to inherit it unmodified.
+func newGitCmd(ctx context.Context, dir string, env []string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
@@ -0,0 +1,103 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Cache is a simple key-value store that LastCommitCache persists its lookups to. Implement it
+// against an in-memory LRU, BoltDB, Redis, or any other backend a caller wants to plug in.
+type Cache interface {
+	// Get returns the value stored under key, and whether it was found.
+	Get(key string) (any, bool)
+	// Put stores val under key.
+	Put(key string, val any) error
+}
+
+// LastCommitCache memoizes "last commit that touched path P at commit C" lookups, the same
+// lookups Entries.GetCommitsInfo performs, so that repeated renders of the same tree at the same
+// commit (e.g. browsing the same directory over and over) never need to shell out to git again.
+type LastCommitCache struct {
+	repo  *Repository
+	cache Cache
+}
+
+// NewLastCommitCache creates a LastCommitCache for repo backed by cache.
+func NewLastCommitCache(repo *Repository, cache Cache) *LastCommitCache {
+	return &LastCommitCache{
+		repo:  repo,
+		cache: cache,
+	}
+}
+
+// cacheKey returns the cache key for entryName within treePath as of commitID, in the
+// "sha:treePath/entryName" form.
+func cacheKey(commitID, treePath, entryName string) string {
+	return commitID + ":" + filepath.Join(treePath, entryName)
+}
+
+// Get returns the last commit known to have touched entryName within treePath as of commitID, or
+// false if there is no cached entry.
+func (c *LastCommitCache) Get(commitID, treePath, entryName string) (*Commit, bool) {
+	val, ok := c.cache.Get(cacheKey(commitID, treePath, entryName))
+	if !ok {
+		return nil, false
+	}
+	lastCommitID, ok := val.(string)
+	if !ok {
+		return nil, false
+	}
+	commit, err := c.repo.GetCommit(lastCommitID)
+	if err != nil {
+		return nil, false
+	}
+	return commit, true
+}
+
+// Put records that commit is the last commit to have touched entryName within treePath as of
+// commitID.
+func (c *LastCommitCache) Put(commitID, treePath, entryName string, commit *Commit) error {
+	return c.cache.Put(cacheKey(commitID, treePath, entryName), commit.ID.String())
+}
+
+// CacheCommit warms the cache for commit by walking its tree once and resolving every entry it
+// reaches, top to bottom, so that later calls to Entries.GetCommitsInfoWithCustomConcurrency that
+// are passed c, for any directory inside commit's tree, are served entirely from the cache.
+// Entries.GetCommitsInfo passes no cache and does not use it.
+func (c *LastCommitCache) CacheCommit(commit *Commit) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("get tree: %v", err)
+	}
+	return c.cacheTree(commit, tree, "")
+}
+
+func (c *LastCommitCache) cacheTree(commit *Commit, tree *Tree, treePath string) error {
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return fmt.Errorf("list entries of %q: %v", treePath, err)
+	}
+
+	infos, err := entries.GetCommitsInfoWithCustomConcurrency(commit, treePath, 0, c)
+	if err != nil {
+		return fmt.Errorf("get commits info of %q: %v", treePath, err)
+	}
+
+	for _, ci := range infos {
+		if !ci.Entry.IsDir() {
+			continue
+		}
+		subTree, err := tree.SubTree(ci.Entry.Name())
+		if err != nil {
+			return fmt.Errorf("get sub tree %q: %v", ci.Entry.Name(), err)
+		}
+		if err := c.cacheTree(commit, subTree, filepath.Join(treePath, ci.Entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
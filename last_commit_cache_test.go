@@ -0,0 +1,64 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import "testing"
+
+// mapCache is a trivial in-memory Cache used to exercise LastCommitCache in tests.
+type mapCache map[string]any
+
+func (c mapCache) Get(key string) (any, bool) {
+	val, ok := c[key]
+	return val, ok
+}
+
+func (c mapCache) Put(key string, val any) error {
+	c[key] = val
+	return nil
+}
+
+func TestLastCommitCache_CacheCommit(t *testing.T) {
+	repo, commit, lastCommit := setupCommitsInfoFixture(t, 10)
+
+	backing := mapCache{}
+	cache := NewLastCommitCache(repo, backing)
+	if err := cache.CacheCommit(commit); err != nil {
+		t.Fatalf("CacheCommit: %v", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+	for _, te := range entries {
+		c, ok := cache.Get(commit.ID.String(), "", te.Name())
+		if !ok {
+			t.Errorf("entry %q was not cached", te.Name())
+			continue
+		}
+		if want := lastCommit[te.Name()]; c.ID.String() != want {
+			t.Errorf("entry %q cached commit %s, want %s", te.Name(), c.ID.String(), want)
+		}
+	}
+
+	// A second pass over the same tree must resolve to the same, correct commits purely from the
+	// warmed cache.
+	infos, err := entries.GetCommitsInfoWithCustomConcurrency(commit, "", 0, cache)
+	if err != nil {
+		t.Fatalf("GetCommitsInfoWithCustomConcurrency: %v", err)
+	}
+	if len(infos) != len(entries) {
+		t.Fatalf("got %d infos, want %d", len(infos), len(entries))
+	}
+	for _, ci := range infos {
+		if want := lastCommit[ci.Entry.Name()]; ci.Commit == nil || ci.Commit.ID.String() != want {
+			t.Errorf("entry %q resolved to %v, want %s", ci.Entry.Name(), ci.Commit, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PushOptions contains options for the Push function.
+type PushOptions struct {
+	// Remote is the remote repository to push to, e.g. "origin".
+	Remote string
+	// Branch is the branch (or refspec) to push, e.g. "master" or "master:master".
+	Branch string
+	// Force indicates whether to force-push, overwriting the remote's history.
+	Force bool
+	// Env is appended to the subprocess's environment, e.g. to set GIT_SSH_COMMAND or
+	// GIT_ASKPASS for authentication. Leave nil to inherit the parent process's environment
+	// unmodified.
+	Env []string
+	// Timeout is the maximum amount of time to wait for the push to complete. Zero means no
+	// timeout.
+	Timeout time.Duration
+}
+
+// PushWithOptions pushes local changes in repoPath to a remote branch, as described by opts.
+func PushWithOptions(repoPath string, opts PushOptions) error {
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	args := []string{"push"}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+	args = append(args, opts.Remote, opts.Branch)
+
+	cmd := newGitCmd(ctx, repoPath, opts.Env, args...)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git push: %v - %s", err, out)
+	}
+	return nil
+}
+
+// Push pushes local changes in repoPath to remote/branch.
+//
+// Deprecated: use PushWithOptions instead. Push will be removed in a future release.
+func Push(repoPath, remote, branch string) error {
+	return PushWithOptions(repoPath, PushOptions{
+		Remote: remote,
+		Branch: branch,
+	})
+}
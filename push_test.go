@@ -0,0 +1,84 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPush(t *testing.T) {
+	remoteDir, err := os.MkdirTemp("", "git-module-push-remote")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(remoteDir) })
+	if _, err := NewCommand("init", "--bare").RunInDir(remoteDir); err != nil {
+		t.Fatalf("git init --bare: %v", err)
+	}
+
+	localDir, err := os.MkdirTemp("", "git-module-push-local")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(localDir) })
+
+	run := func(args ...string) {
+		if _, err := NewCommand(args...).RunInDir(localDir); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	run("init")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	if err := os.WriteFile(filepath.Join(localDir, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	run("add", "file.txt")
+	run("commit", "-m", "initial commit")
+	run("remote", "add", "origin", remoteDir)
+
+	if err := PushWithOptions(localDir, PushOptions{Remote: "origin", Branch: "master"}); err != nil {
+		t.Fatalf("PushWithOptions: %v", err)
+	}
+}
+
+func TestPush_Deprecated(t *testing.T) {
+	remoteDir, err := os.MkdirTemp("", "git-module-push-remote")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(remoteDir) })
+	if _, err := NewCommand("init", "--bare").RunInDir(remoteDir); err != nil {
+		t.Fatalf("git init --bare: %v", err)
+	}
+
+	localDir, err := os.MkdirTemp("", "git-module-push-local")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(localDir) })
+
+	run := func(args ...string) {
+		if _, err := NewCommand(args...).RunInDir(localDir); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	run("init")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	if err := os.WriteFile(filepath.Join(localDir, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	run("add", "file.txt")
+	run("commit", "-m", "initial commit")
+	run("remote", "add", "origin", remoteDir)
+
+	// The old positional signature must keep working unchanged.
+	if err := Push(localDir, "origin", "master"); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tree is a flattened view of one directory (or the repository root) as of a particular commit.
+// Its fields are populated the same way regardless of backend; only listing its children
+// (ListEntries, in tree_nogogit.go and tree_gogit.go) differs between the exec-based and
+// gogit-based builds.
+type Tree struct {
+	ID   SHA1
+	repo *Repository
+
+	parent *Tree
+}
+
+// NewTree returns the Tree for the given object ID in repo.
+func NewTree(repo *Repository, id SHA1) *Tree {
+	return &Tree{ID: id, repo: repo}
+}
+
+// SubTree returns the Tree for the sub-directory at relpath, e.g. "src/pkg", walking one path
+// component at a time through ListEntries.
+func (t *Tree) SubTree(relpath string) (*Tree, error) {
+	if len(relpath) == 0 {
+		return t, nil
+	}
+
+	tree := t
+	for _, name := range strings.Split(relpath, "/") {
+		entries, err := tree.ListEntries()
+		if err != nil {
+			return nil, err
+		}
+
+		var next *Tree
+		for _, te := range entries {
+			if te.name != name {
+				continue
+			}
+			if !te.IsDir() && !te.IsSubModule() {
+				return nil, fmt.Errorf("%s is not a tree", name)
+			}
+			next = NewTree(tree.repo, te.ID)
+			next.parent = tree
+			break
+		}
+		if next == nil {
+			return nil, fmt.Errorf("tree entry %q does not exist", name)
+		}
+		tree = next
+	}
+	return tree, nil
+}
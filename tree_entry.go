@@ -5,11 +5,7 @@
 package git
 
 import (
-	"path/filepath"
-	"runtime"
 	"sort"
-	"strconv"
-	"strings"
 )
 
 // EntryMode the type of the object in the git tree
@@ -30,7 +26,9 @@ const (
 	EntryModeTree EntryMode = 0040000
 )
 
-// TreeEntry the leaf in the git tree
+// TreeEntry the leaf in the git tree. Its fields are populated the same way regardless of
+// backend; only the methods that need to read object data (Size, in tree_entry_nogogit.go and
+// tree_entry_gogit.go) differ between the exec-based and gogit-based builds.
 type TreeEntry struct {
 	ID   SHA1
 	Type ObjectType
@@ -51,24 +49,6 @@ func (te *TreeEntry) Name() string {
 	return te.name
 }
 
-// Size returns the size of the entry
-func (te *TreeEntry) Size() int64 {
-	if te.IsDir() {
-		return 0
-	} else if te.sized {
-		return te.size
-	}
-
-	stdout, err := NewCommand("cat-file", "-s", te.ID.String()).RunInDir(te.ptree.repo.Path)
-	if err != nil {
-		return 0
-	}
-
-	te.sized = true
-	te.size, _ = strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
-	return te.size
-}
-
 // IsSubModule if the entry is a sub module
 func (te *TreeEntry) IsSubModule() bool {
 	return te.mode == EntryModeCommit
@@ -145,129 +125,10 @@ func (tes Entries) Sort() {
 	sort.Sort(tes)
 }
 
-type taskResult struct {
-	commit string
-	paths  []string
-}
-
-func logCommand(currentCommit, treePath string) *Command {
-	var commitHash string
-	if len(currentCommit) == 0 {
-		commitHash = "HEAD"
-	} else {
-		commitHash = currentCommit + "^"
-	}
-	return NewCommand("log", prettyLogFormat, "--name-only", "-2", commitHash, "--", treePath)
-}
-
-func getCommitInfos(headCommit *Commit, currentCommit, treePath string) (*taskResult, error) {
-	logOutput, err := logCommand(currentCommit, treePath).RunInDir(headCommit.repo.Path)
-	if err != nil {
-		return nil, err
-	}
-	lines := strings.Split(logOutput, "\n")
-	paths := make([]string, len(lines)) //TODO
-	/*
-		i := 0
-		for i < len(lines) {
-			state.nextCommit(lines[i])
-			i++
-			for ; i < len(lines); i++ {
-				path := lines[i]
-				if path == "" {
-					break
-				}
-				state.update(path)
-			}
-			i++ // skip blank line
-			if len(state.entries) == len(state.commits) {
-				break
-			}
-		}
-	*/
-	return &taskResult{
-		commit: currentCommit,
-		paths:  paths,
-	}, nil
-}
-
-// GetCommitsInfoWithCustomConcurrency takes advantages of concurrency to speed up getting information
-func (tes Entries) GetCommitsInfoWithCustomConcurrency(headCommit *Commit, treePath string, maxConcurrency int) ([][]interface{}, error) {
-	//Init
-	commitsInfo := make([][]interface{}, len(tes))             //TODO
-	commitsMapInfo := make(map[string][]interface{}, len(tes)) //TODO
-	if maxConcurrency <= 0 {
-		maxConcurrency = runtime.NumCPU()
-	}
-	done := make(chan bool)
-	chanTask := make(chan taskResult, maxConcurrency)
-	chanResponse := make(chan taskResult, maxConcurrency*10) //TODO find perferct size
-	nbStarted := 0
-	nbRunning := 0
-	nbCommitParsing := 4
-	nextPathMissing := 0 //Index in tes entry of next not found entry
-
-	//Start thread for parsing
-	go func() {
-		for result := range chanResponse { //TODO check nil
-			for _, path := range result.paths {
-				relPath, err := filepath.Rel(treePath, path)
-				log("%v %v", relPath, err)
-			}
-			if len(tes) == len(commitsInfo) {
-				break //Finish line
-			}
-		}
-		done <- true
-	}()
-
-	//Start threads if we miss information
-	for len(tes) > len(commitsInfo) {
-		if (len(tes) - len(commitsInfo)) <= (maxConcurrency + nbStarted) { //We have only few file to found commit compared to allready run and number of goroutine //TODO analyze
-			go func() {
-				for ; nextPathMissing < len(tes); nextPathMissing++ {
-					if _, ok := commitsMapInfo[tes[nextPathMissing].Name()]; !ok {
-						break //Found the nextPathMissing
-					}
-				}
-				//TODO detect end and multiple access to nextPathMissing
-				c, err := headCommit.GetCommitByPath(filepath.Join(treePath, tes[nextPathMissing].Name()))
-				chanTask <- err
-				chanResponse <- taskResult{
-					commit: c,
-					paths:  []string{tes[i].Name()},
-				}
-			}()
-		} else {
-			go func() {
-				currentCommit := headCommit.ID.String() //TODO maybe used HEAD~4 ????
-				r, err := getCommitInfos(headCommit, currentCommit, treePath)
-				chanTask <- err
-				chanResponse <- r
-			}()
-		}
-		nbRunning++
-		nbStarted++
-
-		if nbRunning >= maxConcurrency || (len(tes)-len(commitsInfo)) <= (nbStarted) { //Wait for a routine to finish because max running or waiting for end //TODO analyze
-			err <- chanTask
-			if err != nil {
-				return nil, err
-			}
-			nbRunning--
-		}
-		if nbStarted%maxConcurrency == 0 && nbStarted > 0 {
-			nbCommitParsing *= 2
-		}
-	}
-
-	//TODO handle submodule
-	//TODO check that all go routine are finished
-	<-done
-	return commitsInfo, nil
-}
-
-// GetCommitsInfo gets information of all commits that are corresponding to these entries
-func (tes Entries) GetCommitsInfo(commit *Commit, treePath string) ([][]interface{}, error) {
-	return tes.GetCommitsInfoWithCustomConcurrency(commit, treePath, 0)
+// CommitInfo is the commit information of a tree entry: the commit that last touched it and,
+// for submodules, the resolved submodule reference.
+type CommitInfo struct {
+	Entry         *TreeEntry
+	Commit        *Commit
+	SubModuleFile *SubModuleFile
 }
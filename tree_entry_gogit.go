@@ -0,0 +1,126 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build gogit
+// +build gogit
+
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Size returns the size of the entry, read directly from the decoded object in the repository's
+// object store instead of forking a `git cat-file -s` process.
+func (te *TreeEntry) Size() int64 {
+	if te.IsDir() {
+		return 0
+	} else if te.sized {
+		return te.size
+	}
+
+	obj, err := te.ptree.repo.gogitRepo.Storer.EncodedObject(plumbing.AnyObject, plumbing.Hash(te.ID))
+	if err != nil {
+		return 0
+	}
+
+	te.sized = true
+	te.size = obj.Size()
+	return te.size
+}
+
+// GetCommitsInfoWithCustomConcurrency returns the CommitInfo of every entry in tes, i.e. the
+// newest commit that touched each one relative to headCommit. It walks the in-process commit
+// graph via go-git instead of shelling out to `git log`, so there is no fork/exec per entry or
+// per chunk; maxConcurrency is accepted only to keep the signature identical to the !gogit build
+// and is otherwise unused. cache may be nil, in which case every entry is resolved by walking
+// history; otherwise already-known entries are served from the cache and newly resolved ones are
+// stored back into it.
+func (tes Entries) GetCommitsInfoWithCustomConcurrency(headCommit *Commit, treePath string, maxConcurrency int, cache *LastCommitCache) ([]CommitInfo, error) {
+	if len(tes) == 0 {
+		return nil, nil
+	}
+
+	commitID := headCommit.ID.String()
+	unresolved := make(map[string]*TreeEntry, len(tes))
+	resolved := make(map[string]*Commit, len(tes))
+	for _, te := range tes {
+		if cache != nil {
+			if c, ok := cache.Get(commitID, treePath, te.Name()); ok {
+				resolved[te.Name()] = c
+				continue
+			}
+		}
+		unresolved[te.Name()] = te
+	}
+
+	if len(unresolved) > 0 {
+		iter, err := headCommit.repo.gogitRepo.Log(&gogit.LogOptions{
+			From: plumbing.Hash(headCommit.ID),
+			PathFilter: func(p string) bool {
+				_, ok := unresolved[firstPathComponent(treePath, p)]
+				return ok
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("log: %v", err)
+		}
+
+		err = iter.ForEach(func(gc *object.Commit) error {
+			if len(unresolved) == 0 {
+				return storer.ErrStop
+			}
+			c, err := headCommit.repo.getCommit(gc.Hash)
+			if err != nil {
+				return fmt.Errorf("get commit %s: %v", gc.Hash, err)
+			}
+			stats, err := gc.Stats()
+			if err != nil {
+				return fmt.Errorf("stats of commit %s: %v", gc.Hash, err)
+			}
+			for _, fs := range stats {
+				name := firstPathComponent(treePath, fs.Name)
+				if te, ok := unresolved[name]; ok {
+					resolved[te.Name()] = c
+					delete(unresolved, name)
+					if cache != nil {
+						if err := cache.Put(commitID, treePath, name, c); err != nil {
+							log("last_commit_cache: put %q: %v", name, err)
+						}
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil && err != storer.ErrStop {
+			return nil, err
+		}
+	}
+
+	infos := make([]CommitInfo, len(tes))
+	for i, te := range tes {
+		ci := CommitInfo{
+			Entry:  te,
+			Commit: resolved[te.Name()],
+		}
+		if te.IsSubModule() && ci.Commit != nil {
+			if sub, err := headCommit.GetSubModule(filepath.Join(treePath, te.Name())); err == nil && sub != nil {
+				ci.SubModuleFile = NewSubModuleFile(ci.Commit, sub.URL, te.ID.String())
+			}
+		}
+		infos[i] = ci
+	}
+	return infos, nil
+}
+
+// GetCommitsInfo gets information of all commits that are corresponding to these entries
+func (tes Entries) GetCommitsInfo(commit *Commit, treePath string) ([]CommitInfo, error) {
+	return tes.GetCommitsInfoWithCustomConcurrency(commit, treePath, 0, nil)
+}
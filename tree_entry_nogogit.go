@@ -0,0 +1,349 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Size returns the size of the entry, read via the repository's long-lived `git cat-file
+// --batch-check` reader rather than forking a `git cat-file -s` process per call.
+func (te *TreeEntry) Size() int64 {
+	if te.IsDir() {
+		return 0
+	} else if te.sized {
+		return te.size
+	}
+
+	batch, err := repoCatFileBatch(te.ptree.repo)
+	if err != nil {
+		return 0
+	}
+	header, err := batch.check.Check(te.ID.String())
+	if err != nil {
+		return 0
+	}
+
+	te.sized = true
+	te.size = header.Size
+	return te.size
+}
+
+// commitsInfoUntargetedStartChunk is the number of commits walked by the first pass of the
+// untargeted scan. It doubles after every pass that still finds unresolved entries.
+const commitsInfoUntargetedStartChunk = 16
+
+// commitsInfoStopAfterIdleChunks and commitsInfoStopAtRemaining bound the untargeted scan: once
+// it has gone this many chunks without resolving a new entry, and this few entries are still
+// unresolved, it is cheaper to let the targeted workers finish them off one by one.
+const (
+	commitsInfoStopAfterIdleChunks = 5
+	commitsInfoStopAtRemaining     = 12
+)
+
+// commitInfoState is the state shared between the untargeted scanner and the targeted workers
+// while resolving CommitInfo for a set of entries. All access is guarded by mu.
+type commitInfoState struct {
+	mu         sync.Mutex
+	unresolved map[string]*TreeEntry
+	resolved   map[string]*Commit
+	err        error
+
+	commitID string
+	treePath string
+	cache    *LastCommitCache
+}
+
+func newCommitInfoState(headCommit *Commit, treePath string, tes Entries, cache *LastCommitCache) *commitInfoState {
+	s := &commitInfoState{
+		unresolved: make(map[string]*TreeEntry, len(tes)),
+		resolved:   make(map[string]*Commit, len(tes)),
+		commitID:   headCommit.ID.String(),
+		treePath:   treePath,
+		cache:      cache,
+	}
+	for _, te := range tes {
+		if cache != nil {
+			if c, ok := cache.Get(s.commitID, treePath, te.Name()); ok {
+				s.resolved[te.Name()] = c
+				continue
+			}
+		}
+		s.unresolved[te.Name()] = te
+	}
+	return s
+}
+
+// remaining returns the number of entries still without a resolved commit.
+func (s *commitInfoState) remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.unresolved)
+}
+
+// resolve records the commit that last touched name, if it is still unresolved, and writes it
+// through to the cache, if any.
+func (s *commitInfoState) resolve(name string, commit *Commit) bool {
+	s.mu.Lock()
+	if _, ok := s.unresolved[name]; !ok {
+		s.mu.Unlock()
+		return false
+	}
+	s.resolved[name] = commit
+	delete(s.unresolved, name)
+	s.mu.Unlock()
+
+	if s.cache != nil {
+		if err := s.cache.Put(s.commitID, s.treePath, name, commit); err != nil {
+			log("last_commit_cache: put %q: %v", name, err)
+		}
+	}
+	return true
+}
+
+// claimNext removes and returns an arbitrary unresolved entry for a targeted worker to handle,
+// or nil once nothing is left.
+func (s *commitInfoState) claimNext() *TreeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, te := range s.unresolved {
+		delete(s.unresolved, name)
+		return te
+	}
+	return nil
+}
+
+func (s *commitInfoState) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *commitInfoState) getErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// untargetedScan walks history from headCommit in exponentially growing chunks, looking at the
+// paths touched by each commit to assign the newest commit to every still-unresolved entry. It
+// gives up once it has stopped making progress while only a handful of entries remain, once it
+// reaches the root of history, or when it hits an error, leaving whatever is left to the
+// targeted workers. A scan-side error is only
+// logged, never fatal: the targeted pool can still resolve the remaining entries on its own.
+func (s *commitInfoState) untargetedScan(headCommit *Commit, treePath string) {
+	repoPath := headCommit.repo.Path
+	chunkSize := commitsInfoUntargetedStartChunk
+	fromCommit := headCommit.ID.String()
+	idleChunks := 0
+
+	for s.remaining() > 0 {
+		stdout, err := NewCommand("log", prettyLogFormat, "--name-only", "-"+strconv.Itoa(chunkSize), fromCommit, "--", logPathspec(treePath)).RunInDir(repoPath)
+		if err != nil {
+			log("get_commits_info: untargeted scan: git log --name-only: %v", err)
+			return
+		}
+
+		blocks := parseNameOnlyLog(stdout)
+		if len(blocks) == 0 {
+			return // reached the root of history
+		}
+
+		resolvedThisChunk := 0
+		for _, block := range blocks {
+			c, err := headCommit.repo.GetCommit(block.commitHash)
+			if err != nil {
+				log("get_commits_info: untargeted scan: get commit %s: %v", block.commitHash, err)
+				return
+			}
+			for _, path := range block.paths {
+				name := firstPathComponent(treePath, path)
+				if name == "" {
+					continue
+				}
+				if s.resolve(name, c) {
+					resolvedThisChunk++
+				}
+			}
+		}
+
+		if resolvedThisChunk == 0 {
+			idleChunks++
+		} else {
+			idleChunks = 0
+		}
+
+		if idleChunks >= commitsInfoStopAfterIdleChunks && s.remaining() <= commitsInfoStopAtRemaining {
+			return
+		}
+
+		fromCommit = blocks[len(blocks)-1].commitHash + "^"
+		chunkSize *= 2
+	}
+}
+
+// logPathspec returns the pathspec to pass to `git log -- <pathspec>` for treePath. Modern git
+// rejects an empty string ("fatal: empty string is not a valid pathspec"), so the repository
+// root has to be spelled out as ".".
+func logPathspec(treePath string) string {
+	if treePath == "" {
+		return "."
+	}
+	return treePath
+}
+
+// nameOnlyBlock is one "<commit>\n<path>\n<path>\n..." block from `git log --name-only`.
+type nameOnlyBlock struct {
+	commitHash string
+	paths      []string
+}
+
+// parseNameOnlyLog parses the output of `git log <prettyLogFormat> --name-only`, where each
+// commit is rendered as its hash on its own line followed by the paths it touched, with blocks
+// separated by a blank line.
+func parseNameOnlyLog(output string) []nameOnlyBlock {
+	lines := strings.Split(output, "\n")
+	var blocks []nameOnlyBlock
+	i := 0
+	for i < len(lines) {
+		hash := strings.TrimSpace(lines[i])
+		i++
+		if hash == "" {
+			continue
+		}
+		block := nameOnlyBlock{commitHash: hash}
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+			block.paths = append(block.paths, unquotePath(lines[i]))
+			i++
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// unquotePath decodes a path as emitted by `git log --name-only`. With the default
+// core.quotePath=true, git double-quotes and C-escapes any path containing non-ASCII or
+// otherwise "unusual" characters; plain paths are returned unchanged.
+func unquotePath(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		return s
+	}
+	return unquoted
+}
+
+// firstPathComponent returns the entry name directly under treePath that owns path (for a path
+// nested deeper, the directory entry that contains it), or "" if path is treePath itself or lies
+// outside it.
+func firstPathComponent(treePath, path string) string {
+	rel, err := filepath.Rel(treePath, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if idx := strings.IndexRune(rel, filepath.Separator); idx >= 0 {
+		rel = rel[:idx]
+	}
+	return rel
+}
+
+// targetedResolve runs a single `git log -1 -- path` lookup for te and records the result.
+func targetedResolve(s *commitInfoState, headCommit *Commit, treePath string, te *TreeEntry) {
+	fullPath := filepath.Join(treePath, te.Name())
+	c, err := headCommit.GetCommitByPath(fullPath)
+	if err != nil {
+		s.setErr(fmt.Errorf("git log -1 -- %s: %v", fullPath, err))
+		return
+	}
+	// te was already taken out of s.unresolved by claimNext, so record it directly rather than
+	// through resolve, which only accepts still-unresolved names.
+	s.mu.Lock()
+	s.resolved[te.Name()] = c
+	s.mu.Unlock()
+	if s.cache != nil {
+		if err := s.cache.Put(s.commitID, s.treePath, te.Name(), c); err != nil {
+			log("last_commit_cache: put %q: %v", te.Name(), err)
+		}
+	}
+}
+
+// GetCommitsInfoWithCustomConcurrency returns the CommitInfo of every entry in tes, i.e. the
+// newest commit that touched each one relative to headCommit. It first runs an untargeted scan
+// that walks history in exponentially growing chunks, resolving the bulk of entries in a handful
+// of `git log --name-only` calls; only what that scan gives up on (see
+// commitsInfoStopAfterIdleChunks/commitsInfoStopAtRemaining) is handed to a bounded pool of
+// targeted, per-entry `git log` lookups, so the targeted pool never has to fork one process per
+// entry for a whole directory. maxConcurrency caps the number of targeted workers; 0 or less
+// defaults to runtime.NumCPU(). cache may be nil, in which case every entry is resolved from git;
+// otherwise already-known entries are served from the cache and newly resolved ones are stored
+// back into it.
+func (tes Entries) GetCommitsInfoWithCustomConcurrency(headCommit *Commit, treePath string, maxConcurrency int, cache *LastCommitCache) ([]CommitInfo, error) {
+	if len(tes) == 0 {
+		return nil, nil
+	}
+	if maxConcurrency <= 0 {
+		maxConcurrency = runtime.NumCPU()
+	}
+
+	state := newCommitInfoState(headCommit, treePath, tes, cache)
+
+	state.untargetedScan(headCommit, treePath)
+
+	var workers sync.WaitGroup
+	for i := 0; i < maxConcurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				if state.getErr() != nil {
+					return
+				}
+				te := state.claimNext()
+				if te == nil {
+					return
+				}
+				targetedResolve(state, headCommit, treePath, te)
+			}
+		}()
+	}
+
+	workers.Wait()
+
+	if err := state.getErr(); err != nil {
+		return nil, err
+	}
+
+	infos := make([]CommitInfo, len(tes))
+	for i, te := range tes {
+		ci := CommitInfo{
+			Entry:  te,
+			Commit: state.resolved[te.Name()],
+		}
+		if te.IsSubModule() && ci.Commit != nil {
+			if sub, err := headCommit.GetSubModule(filepath.Join(treePath, te.Name())); err == nil && sub != nil {
+				ci.SubModuleFile = NewSubModuleFile(ci.Commit, sub.URL, te.ID.String())
+			}
+		}
+		infos[i] = ci
+	}
+	return infos, nil
+}
+
+// GetCommitsInfo gets information of all commits that are corresponding to these entries
+func (tes Entries) GetCommitsInfo(commit *Commit, treePath string) ([]CommitInfo, error) {
+	return tes.GetCommitsInfoWithCustomConcurrency(commit, treePath, 0, nil)
+}
@@ -0,0 +1,106 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupCommitsInfoFixture creates a throwaway repository with numFiles files at the root, each
+// added by its own commit, so the commit that last touched file-N.txt is known upfront. It
+// returns the opened repository, its HEAD commit, and a map from file name to the hash of the
+// commit that added it.
+func setupCommitsInfoFixture(t testing.TB, numFiles int) (*Repository, *Commit, map[string]string) {
+	dir, err := os.MkdirTemp("", "git-module-commits-info")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	run := func(args ...string) string {
+		stdout, err := NewCommand(args...).RunInDir(dir)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return stdout
+	}
+
+	run("init")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+
+	lastCommit := make(map[string]string, numFiles)
+	for i := 0; i < numFiles; i++ {
+		name := fmt.Sprintf("file-%d.txt", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		run("add", name)
+		run("commit", "-m", "add "+name)
+		lastCommit[name] = strings.TrimSpace(run("rev-parse", "HEAD"))
+	}
+
+	repo, err := OpenRepository(dir)
+	if err != nil {
+		t.Fatalf("OpenRepository: %v", err)
+	}
+	commit, err := repo.GetBranchCommit("master")
+	if err != nil {
+		t.Fatalf("GetBranchCommit: %v", err)
+	}
+	return repo, commit, lastCommit
+}
+
+func TestEntries_GetCommitsInfo(t *testing.T) {
+	_, commit, lastCommit := setupCommitsInfoFixture(t, 20)
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		t.Fatalf("ListEntries: %v", err)
+	}
+
+	infos, err := entries.GetCommitsInfo(commit, "")
+	if err != nil {
+		t.Fatalf("GetCommitsInfo: %v", err)
+	}
+	if len(infos) != len(entries) {
+		t.Fatalf("got %d infos, want %d", len(infos), len(entries))
+	}
+	for _, ci := range infos {
+		if ci.Commit == nil {
+			t.Errorf("entry %q has no resolved commit", ci.Entry.Name())
+			continue
+		}
+		if want := lastCommit[ci.Entry.Name()]; ci.Commit.ID.String() != want {
+			t.Errorf("entry %q resolved to commit %s, want %s", ci.Entry.Name(), ci.Commit.ID.String(), want)
+		}
+	}
+}
+
+func BenchmarkEntries_GetCommitsInfo(b *testing.B) {
+	_, commit, _ := setupCommitsInfoFixture(b, 500)
+	tree, err := commit.Tree()
+	if err != nil {
+		b.Fatalf("Tree: %v", err)
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		b.Fatalf("ListEntries: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := entries.GetCommitsInfo(commit, ""); err != nil {
+			b.Fatalf("GetCommitsInfo: %v", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build gogit
+// +build gogit
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// ListEntries returns the direct children of t, read from the decoded tree object in the
+// repository's object store instead of forking `git ls-tree`.
+func (t *Tree) ListEntries() (Entries, error) {
+	gogitTree, err := t.repo.gogitRepo.TreeObject(plumbing.Hash(t.ID))
+	if err != nil {
+		return nil, fmt.Errorf("get tree object: %v", err)
+	}
+
+	entries := make(Entries, 0, len(gogitTree.Entries))
+	for _, e := range gogitTree.Entries {
+		entries = append(entries, &TreeEntry{
+			ID:    SHA1(e.Hash),
+			Type:  entryObjectType(e.Mode),
+			mode:  EntryMode(e.Mode),
+			name:  e.Name,
+			ptree: t,
+		})
+	}
+
+	entries.Sort()
+	return entries, nil
+}
+
+// entryObjectType maps a go-git file mode to the ObjectType `git ls-tree` would report for it.
+func entryObjectType(mode filemode.FileMode) ObjectType {
+	switch mode {
+	case filemode.Dir:
+		return ObjectTree
+	case filemode.Submodule:
+		return ObjectCommit
+	default:
+		return ObjectBlob
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2015 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !gogit
+// +build !gogit
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ListEntries returns the direct children of t, parsed from `git ls-tree`.
+func (t *Tree) ListEntries() (Entries, error) {
+	stdout, err := NewCommand("ls-tree", t.ID.String()).RunInDir(t.repo.Path)
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree: %v", err)
+	}
+
+	lines := strings.Split(stdout, "\n")
+	entries := make(Entries, 0, len(lines))
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		// Each line has the form "<mode> <type> <sha>\t<name>".
+		tab := strings.IndexByte(line, '\t')
+		if tab < 0 {
+			return nil, fmt.Errorf("malformed ls-tree line %q", line)
+		}
+		fields := strings.SplitN(line[:tab], " ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed ls-tree line %q", line)
+		}
+
+		mode, err := strconv.ParseInt(fields[0], 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse mode %q: %v", fields[0], err)
+		}
+		id, err := NewIDFromString(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("parse object id %q: %v", fields[2], err)
+		}
+
+		entries = append(entries, &TreeEntry{
+			ID:    id,
+			Type:  ObjectType(fields[1]),
+			mode:  EntryMode(mode),
+			name:  line[tab+1:],
+			ptree: t,
+		})
+	}
+
+	entries.Sort()
+	return entries, nil
+}